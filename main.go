@@ -2,176 +2,229 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/Benjamin-Loison/narr/pkg/adapter"
+	"github.com/Benjamin-Loison/narr/pkg/browser"
+	"github.com/Benjamin-Loison/narr/pkg/config"
+	"github.com/Benjamin-Loison/narr/pkg/downloader"
+	"github.com/Benjamin-Loison/narr/pkg/metrics"
+	"github.com/Benjamin-Loison/narr/pkg/rpc"
 	"github.com/cenkalti/backoff/v4"
 	"github.com/golang-queue/queue"
-	"github.com/mafredri/cdp"
 	"github.com/mafredri/cdp/devtool"
 	"github.com/mafredri/cdp/protocol/network"
-	"github.com/mafredri/cdp/protocol/page"
-	"github.com/mafredri/cdp/rpcc"
-	"io"
-	"log"
-	"math/rand"
-	"net/http"
-	"net/url"
-	"os"
-	"strconv"
-	"strings"
-	"time"
+	"github.com/sirupsen/logrus"
 )
 
+// scrapeTask opens title and arms the audio-download listener on it. A
+// real login/cookie-consent flow would be inserted as Actions ahead of
+// OnResponse; sites like Netflix require an authenticated, interacted-with
+// page before their audio starts streaming.
+func scrapeTask(title string, cfg *config.Config, q *queue.Queue, dl *downloader.Downloader, reg *rpc.Registry) browser.Task {
+	return browser.Task{
+		browser.Navigate(title),
+		browser.OnResponse(func(resp *network.Response) {
+			if err := enqueueDownload(cfg, q, dl, reg, resp.URL); err != nil {
+				logrus.WithError(err).WithField("url", resp.URL).Error("enqueueing download")
+			}
+		}),
+	}
+}
+
 func main() {
-	ctx := context.Background()
-	var chrome *cdp.Client
+	configPath := flag.String("config", "", "path to narr's config file (defaults to a narr.yaml, narr.toml, etc. in the working directory)")
+	statusFlag := flag.Bool("status", false, "print the status of downloads known to a running narr on its socket, then exit")
+	cancelFlag := flag.String("cancel", "", "cancel the download with this job ID on a running narr's socket, then exit")
+	flag.Parse()
 
-	retryFunc := func() error {
-		var err error
-		chrome, err = connectToChromeDebugger(ctx, "http://127.0.0.1:9222")
-		if err != nil {
-			log.Print(fmt.Errorf("can't connect to http://127.0.0.1:9222. Chrome must be started in debug mode. %w", err))
-		}
-		return err
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		logrus.Fatal(err)
 	}
 
-	err := backoff.Retry(retryFunc, backoff.NewConstantBackOff(5*time.Second))
-	if err != nil {
-		log.Fatal(err)
+	if *statusFlag {
+		if err := rpc.PrintStatus(cfg.Socket); err != nil {
+			logrus.Fatal(err)
+		}
+		return
+	}
+	if *cancelFlag != "" {
+		if err := rpc.Cancel(cfg.Socket, *cancelFlag); err != nil {
+			logrus.Fatal(err)
+		}
+		return
 	}
 
-	// Listen to response received events
-	responseReceived, err := chrome.Network.ResponseReceived(ctx)
+	level, err := logrus.ParseLevel(cfg.LogLevel)
 	if err != nil {
-		log.Fatal(err)
+		logrus.Fatal(err)
 	}
+	logrus.SetLevel(level)
 
-	// Enable event stream
-	if err = chrome.Network.Enable(ctx, network.NewEnableArgs()); err != nil {
-		log.Fatal(err)
+	titles := flag.Args()
+	if len(titles) == 0 {
+		titles = cfg.Titles
 	}
 
-	// Open netflix tab
-	navArgs := page.NewNavigateArgs("https://www.netflix.com")
-	_, err = chrome.Page.Navigate(ctx, navArgs)
-	if err != nil {
-		log.Fatal(err)
+	ctx := context.Background()
+
+	metricsServer := metrics.Serve(cfg.MetricsAddr, logrus.StandardLogger())
+	defer metricsServer.Close()
+
+	addr := cfg.DebugAddr
+	if addr == "" {
+		proc, err := browser.Launch(ctx, browser.LaunchOptions{
+			ExecPath:   cfg.Chrome,
+			Headless:   cfg.Headless,
+			ExtraFlags: []string{"--disable-gpu", "--no-sandbox"},
+		})
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		defer proc.Close()
+		addr = proc.DebuggerAddr
+	} else {
+		if err := waitForAttach(ctx, addr, cfg.Backoff); err != nil {
+			logrus.Fatal(err)
+		}
 	}
 
-	defer responseReceived.Close()
+	mgr := browser.NewManager(addr)
+	defer mgr.Close()
 
-	// Initial queue pool for download jobs
-	q := queue.NewPool(8)
+	q := queue.NewPool(cfg.Concurrency)
 	defer q.Release()
 
-	for u := range listen(responseReceived) {
-		if !isAudioURL(u) {
-			continue
-		}
+	dl := downloader.New(cfg.OutDir)
+	dl.Segments = cfg.Segments
 
-		err := enqueueDownload(q, toDownloadableURL(u), "DL-"+strconv.Itoa(rand.Int()))
+	reg := rpc.NewRegistry()
+	rpcServer, err := rpc.Serve(ctx, cfg.Socket, reg)
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	defer rpcServer.Close()
+
+	for _, title := range titles {
+		sess, err := mgr.OpenTab(ctx)
 		if err != nil {
-			log.Println(err)
+			logrus.Fatal(err)
 		}
-	}
-}
 
-// listen to all responses received by the current tab and send us their URLs.
-func listen(responseReceived network.ResponseReceivedClient) chan string {
-	urls := make(chan string)
-	go func() {
-		for {
-			select {
-			case <-responseReceived.Ready():
-				ev, err := responseReceived.Recv()
-				if err != nil {
-					log.Fatal(err)
-				}
-
-				urls <- ev.Response.URL
+		task := scrapeTask(title, cfg, q, dl, reg)
+		go func(t browser.Task, s *browser.Session) {
+			if err := t.Run(ctx, s); err != nil {
+				logrus.WithError(err).WithField("title", title).Error("task failed")
 			}
-		}
-	}()
+		}(task, sess)
+	}
 
-	return urls
+	select {}
 }
 
-// connectToChromeDebugger establishes a debugging session on a remote chrome instance. Chrome must be already started in debug-mode.
-// See https://blog.chromium.org/2011/05/remote-debugging-with-chrome-developer.html for more details
-func connectToChromeDebugger(ctx context.Context, url string) (*cdp.Client, error) {
-	// Use the DevTools HTTP/JSON API to manage targets (e.g. pages, webworkers).
-	devt := devtool.New(url)
-	pt, err := devt.Get(ctx, devtool.Page)
-	if err != nil {
-		pt, err = devt.Create(ctx)
+// waitForAttach retries connecting to an already-running Chrome debugger
+// until it answers, since the user may still be starting it by hand.
+func waitForAttach(ctx context.Context, addr string, backoffInterval time.Duration) error {
+	return backoff.Retry(func() error {
+		devt := devtool.New(addr)
+		_, err := devt.Get(ctx, devtool.Page)
 		if err != nil {
-			return nil, err
+			logrus.WithError(err).Warnf("can't connect to %s, chrome must be started in debug mode", addr)
 		}
-	}
+		return err
+	}, backoff.NewConstantBackOff(backoffInterval))
+}
 
-	// Initiate a new RPC connection to the Chrome DevTools Protocol target.
-	conn, err := rpcc.DialContext(ctx, pt.WebSocketDebuggerURL)
+// adapterFor returns the Adapter configured for the host audioURL was
+// served from, if any site in cfg.Sites matches it.
+func adapterFor(cfg *config.Config, audioURL string) (adapter.Adapter, bool) {
+	parsed, err := url.Parse(audioURL)
 	if err != nil {
-		return nil, err
+		return nil, false
 	}
 
-	return cdp.NewClient(conn), nil
-}
+	name, ok := cfg.AdapterName(parsed.Hostname())
+	if !ok {
+		return nil, false
+	}
 
-// Audio resources have the path format /range/0-nnnn...
-func isAudioURL(u string) bool {
-	return strings.Contains(u, "/range/0-")
+	return adapter.Lookup(name)
 }
 
-// toDownloadableURL removes the path from the url to make the resource downloadable. In our case the path
-// always contains a download-range in bytes which we can discard. See isAudioURL.
-func toDownloadableURL(audioURL string) string {
-	// We need to remove the path from the audio url to get a downloadable url
-	u, err := url.Parse(audioURL)
-	if err != nil {
-		log.Fatal(err)
+// enqueueDownload queues a resumable, range-aware download of audioURL
+// using whichever Adapter is configured for its host, registering it with
+// reg so its progress is visible to "-status" and it can be cancelled or
+// held by "-cancel"/Queue.Pause.
+func enqueueDownload(cfg *config.Config, q *queue.Queue, dl *downloader.Downloader, reg *rpc.Registry, audioURL string) error {
+	a, ok := adapterFor(cfg, audioURL)
+	if !ok || !a.IsAudioURL(audioURL) {
+		return nil
 	}
 
-	u.Path = ""
-	return u.String()
+	size, ok := a.ContentLength(audioURL)
+	if !ok {
+		return fmt.Errorf("%s: could not read content length from %s", a.Name(), audioURL)
+	}
 
-}
+	metrics.QueueDepth.Inc()
 
-func enqueueDownload(q *queue.Queue, fromURL, toPath string) error {
-	go func(s, t string) {
+	go func() {
 		err := q.QueueTask(func(ctx context.Context) error {
-			return download(fromURL, toPath)
-		})
-		if err != nil {
-			return
-		}
-
-	}(fromURL, toPath)
+			defer metrics.QueueDepth.Dec()
 
-	return nil
-}
+			if err := reg.Wait(ctx); err != nil {
+				return err
+			}
 
-func download(fromUrl, toPath string) error {
-	log.Println("Downloading " + fromUrl)
-	out, err := os.Create(toPath)
+			id, jobCtx, attach, finish := reg.Register(ctx, audioURL)
+			log := logrus.WithFields(logrus.Fields{"job": id, "url": audioURL})
+			start := time.Now()
 
-	if err != nil {
-		return err
-	}
+			job, err := dl.Start(jobCtx, a.ToDownloadableURL(audioURL), audioURL, size)
+			if err != nil {
+				finish(err)
+				metrics.DownloadsTotal.WithLabelValues("error").Inc()
+				log.WithError(err).Error("download failed")
+				return err
+			}
+			attach(job)
 
-	defer out.Close()
+			log.WithField("path", job.Path).Info("downloading")
+			err = job.Wait()
+			finish(err)
 
-	resp, err := http.Get(fromUrl)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
+			metrics.DownloadDuration.Observe(time.Since(start).Seconds())
+			metrics.DownloadBytes.Add(float64(job.BytesWritten()))
 
-	n, err := io.Copy(out, resp.Body)
-	if err != nil {
-		return err
-	}
+			if err != nil {
+				metrics.DownloadsTotal.WithLabelValues(stateLabel(err)).Inc()
+				log.WithError(err).Error("download failed")
+				return err
+			}
 
-	log.Printf("Done, got %d bytes", n)
+			metrics.DownloadsTotal.WithLabelValues("done").Inc()
+			log.WithField("bytes", job.BytesWritten()).Info("done")
+			return nil
+		})
+		if err != nil {
+			metrics.QueueDepth.Dec()
+			logrus.WithError(err).WithField("url", audioURL).Error("queueing download")
+		}
+	}()
 
 	return nil
 }
+
+// stateLabel turns a job's terminal error into a narr_downloads_total state
+// label, distinguishing a cancellation from a genuine failure.
+func stateLabel(err error) string {
+	if errors.Is(err, context.Canceled) {
+		return "cancelled"
+	}
+	return "error"
+}
@@ -0,0 +1,43 @@
+// Package adapter turns narr from a Netflix-only scraper into a general
+// audio-capture tool: each supported site implements Adapter and registers
+// itself, and a Config's sites: section picks which one handles a given
+// hostname.
+package adapter
+
+import "sync"
+
+// Adapter knows how to recognize and size a single site's audio resource
+// URLs, and how to rewrite them into a directly fetchable form.
+type Adapter interface {
+	// Name identifies the adapter in a Config's sites: section, e.g. "netflix".
+	Name() string
+	// IsAudioURL reports whether u is a resource this adapter should download.
+	IsAudioURL(u string) bool
+	// ToDownloadableURL rewrites an observed audio URL into one that can be
+	// fetched directly with a Range request.
+	ToDownloadableURL(u string) string
+	// ContentLength reads the resource's total size off u, if the site
+	// exposes it there (e.g. a byte-range path segment or query parameter).
+	ContentLength(u string) (int64, bool)
+}
+
+var (
+	mu       sync.Mutex
+	registry = map[string]Adapter{}
+)
+
+// Register adds a to the registry under its Name. Built-in adapters call
+// this from an init() in their own file.
+func Register(a Adapter) {
+	mu.Lock()
+	defer mu.Unlock()
+	registry[a.Name()] = a
+}
+
+// Lookup returns the registered adapter named name, if any.
+func Lookup(name string) (Adapter, bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	a, ok := registry[name]
+	return a, ok
+}
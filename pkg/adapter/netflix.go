@@ -0,0 +1,57 @@
+package adapter
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+func init() { Register(netflixAdapter{}) }
+
+// netflixAdapter handles Netflix's audio CDN, which serves byte-range
+// segments under a path of the form /range/0-nnnn...
+type netflixAdapter struct{}
+
+func (netflixAdapter) Name() string { return "netflix" }
+
+// IsAudioURL matches Netflix's byte-range audio segment path format.
+func (netflixAdapter) IsAudioURL(u string) bool {
+	return strings.Contains(u, "/range/0-")
+}
+
+// ToDownloadableURL removes the path from the url entirely: Netflix's edge
+// servers serve the resource off the bare host, and the path only carries
+// the byte range ContentLength already parsed out.
+func (netflixAdapter) ToDownloadableURL(u string) string {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return u
+	}
+	parsed.Path = ""
+	return parsed.String()
+}
+
+// ContentLength reads the resource's total size off the "/range/0-N" path,
+// where N is the last byte of an inclusive 0-based range.
+func (netflixAdapter) ContentLength(u string) (int64, bool) {
+	const marker = "/range/0-"
+	i := strings.Index(u, marker)
+	if i < 0 {
+		return 0, false
+	}
+
+	end := u[i+len(marker):]
+	if j := strings.IndexByte(end, '/'); j >= 0 {
+		end = end[:j]
+	}
+	if j := strings.IndexByte(end, '?'); j >= 0 {
+		end = end[:j]
+	}
+
+	n, err := strconv.ParseInt(end, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return n + 1, true
+}
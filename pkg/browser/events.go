@@ -0,0 +1,91 @@
+package browser
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/Benjamin-Loison/narr/pkg/metrics"
+	"github.com/mafredri/cdp/protocol/network"
+	"github.com/mafredri/cdp/rpcc"
+	"github.com/sirupsen/logrus"
+)
+
+// EventHandler receives the raw JSON params of a single CDP event.
+type EventHandler func(params []byte)
+
+// Dispatcher fans out CDP events by method name so several independent
+// callers can watch the same stream, e.g. a login flow and the audio
+// listener both reacting to Network.responseReceived without stepping on
+// each other's subscription.
+type Dispatcher struct {
+	conn *rpcc.Conn
+
+	mu       sync.Mutex
+	handlers map[string][]EventHandler
+}
+
+func newDispatcher(conn *rpcc.Conn) *Dispatcher {
+	return &Dispatcher{
+		conn:     conn,
+		handlers: make(map[string][]EventHandler),
+	}
+}
+
+// On registers handler to run whenever method is observed on the tab. The
+// underlying subscription is started lazily, once per method.
+func (d *Dispatcher) On(ctx context.Context, method string, handler EventHandler) error {
+	d.mu.Lock()
+	first := len(d.handlers[method]) == 0
+	d.handlers[method] = append(d.handlers[method], handler)
+	d.mu.Unlock()
+
+	if !first {
+		return nil
+	}
+
+	stream, err := rpcc.NewStream(ctx, method, d.conn)
+	if err != nil {
+		return err
+	}
+
+	go d.pump(method, stream)
+	return nil
+}
+
+// pump delivers every message on stream to the handlers currently
+// registered for method, until the stream is closed.
+func (d *Dispatcher) pump(method string, stream rpcc.Stream) {
+	defer stream.Close()
+	for range stream.Ready() {
+		var raw json.RawMessage
+		if err := stream.RecvMsg(&raw); err != nil {
+			logrus.WithError(err).WithField("method", method).Debug("cdp stream closed")
+			return
+		}
+
+		metrics.CDPEventsTotal.WithLabelValues(method).Inc()
+
+		d.mu.Lock()
+		handlers := append([]EventHandler(nil), d.handlers[method]...)
+		d.mu.Unlock()
+
+		for _, h := range handlers {
+			h(raw)
+		}
+	}
+}
+
+// OnNetworkResponse is a typed convenience wrapper around On for the one
+// event narr actually cares about end to end: a resource finishing its
+// response headers.
+func (d *Dispatcher) OnNetworkResponse(ctx context.Context, handler func(*network.Response)) error {
+	return d.On(ctx, "Network.responseReceived", func(raw []byte) {
+		var ev network.ResponseReceivedReply
+		if err := json.Unmarshal(raw, &ev); err != nil {
+			logrus.WithError(err).Warn("decoding Network.responseReceived")
+			return
+		}
+		handler(&ev.Response)
+	})
+}
@@ -0,0 +1,119 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/mafredri/cdp/devtool"
+)
+
+// LaunchOptions configures a headless Chromium instance started by Launch.
+type LaunchOptions struct {
+	// ExecPath is the path to the Chromium/Chrome binary. Defaults to "chromium".
+	ExecPath string
+	// DebuggingPort is the remote-debugging port Chrome listens on. Defaults to 9222.
+	DebuggingPort int
+	// UserDataDir is the profile directory passed via --user-data-dir. A
+	// temporary directory is created and cleaned up on Close when empty.
+	UserDataDir string
+	// Headless starts Chrome with --headless=new instead of a visible window.
+	Headless bool
+	// ExtraFlags are appended verbatim to the Chrome command line, e.g.
+	// "--disable-gpu" or "--no-sandbox".
+	ExtraFlags []string
+}
+
+// Process is a Chromium instance started by Launch, in debugging mode.
+type Process struct {
+	cmd             *exec.Cmd
+	DebuggerAddr    string
+	userDataDir     string
+	ownsUserDataDir bool
+}
+
+// Launch starts a new Chromium process in debugging mode and waits for its
+// DevTools HTTP endpoint to answer before returning, so the caller can dial
+// it immediately with NewManager. The returned Process must be Closed to
+// terminate Chrome and remove any profile directory Launch created.
+func Launch(ctx context.Context, opts LaunchOptions) (*Process, error) {
+	if opts.ExecPath == "" {
+		opts.ExecPath = "chromium"
+	}
+	if opts.DebuggingPort == 0 {
+		opts.DebuggingPort = 9222
+	}
+
+	ownsUserDataDir := opts.UserDataDir == ""
+	if ownsUserDataDir {
+		dir, err := os.MkdirTemp("", "narr-chrome-")
+		if err != nil {
+			return nil, fmt.Errorf("creating user-data-dir: %w", err)
+		}
+		opts.UserDataDir = dir
+	}
+
+	args := []string{
+		fmt.Sprintf("--remote-debugging-port=%d", opts.DebuggingPort),
+		"--user-data-dir=" + opts.UserDataDir,
+		"--disable-gpu",
+		"--no-sandbox",
+	}
+	if opts.Headless {
+		args = append(args, "--headless=new")
+	}
+	args = append(args, opts.ExtraFlags...)
+
+	cmd := exec.CommandContext(ctx, opts.ExecPath, args...)
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("starting %s: %w", opts.ExecPath, err)
+	}
+
+	addr := fmt.Sprintf("http://127.0.0.1:%d", opts.DebuggingPort)
+	if err := waitForDebugger(ctx, addr); err != nil {
+		_ = cmd.Process.Kill()
+		return nil, err
+	}
+
+	return &Process{
+		cmd:             cmd,
+		DebuggerAddr:    addr,
+		userDataDir:     opts.UserDataDir,
+		ownsUserDataDir: ownsUserDataDir,
+	}, nil
+}
+
+// Close terminates the Chrome process and, if Launch generated the profile
+// directory itself, removes it.
+func (p *Process) Close() error {
+	if p.cmd.Process != nil {
+		_ = p.cmd.Process.Kill()
+		_, _ = p.cmd.Process.Wait()
+	}
+	if p.ownsUserDataDir {
+		return os.RemoveAll(p.userDataDir)
+	}
+	return nil
+}
+
+// waitForDebugger polls the DevTools HTTP API until it responds, ctx is
+// done, or 10 seconds have passed.
+func waitForDebugger(ctx context.Context, addr string) error {
+	devt := devtool.New(addr)
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		if _, err := devt.Version(ctx); err == nil {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+
+	return fmt.Errorf("chrome debugger at %s did not come up in time", addr)
+}
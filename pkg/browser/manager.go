@@ -0,0 +1,58 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mafredri/cdp/devtool"
+)
+
+// Manager discovers and multiplexes DevTools targets on a single Chrome
+// debugger endpoint, letting a caller drive several tabs concurrently from
+// one process instead of being limited to a single attached page.
+type Manager struct {
+	devt     *devtool.DevTools
+	sessions map[devtool.TargetID]*Session
+}
+
+// NewManager creates a Manager pointed at the DevTools HTTP API served at
+// addr, e.g. "http://127.0.0.1:9222".
+func NewManager(addr string) *Manager {
+	return &Manager{
+		devt:     devtool.New(addr),
+		sessions: make(map[devtool.TargetID]*Session),
+	}
+}
+
+// OpenTab creates a new browser tab and attaches a Session to it.
+func (m *Manager) OpenTab(ctx context.Context) (*Session, error) {
+	target, err := m.devt.Create(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("creating target: %w", err)
+	}
+
+	sess, err := Attach(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+
+	m.sessions[target.ID] = sess
+	return sess, nil
+}
+
+// Targets lists every target of any type currently open on the debugger, including
+// tabs opened outside of this Manager.
+func (m *Manager) Targets(ctx context.Context) ([]*devtool.Target, error) {
+	return m.devt.List(ctx)
+}
+
+// Close closes every session this Manager has opened.
+func (m *Manager) Close() error {
+	var firstErr error
+	for _, sess := range m.sessions {
+		if err := sess.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,58 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mafredri/cdp"
+	"github.com/mafredri/cdp/devtool"
+	"github.com/mafredri/cdp/protocol/network"
+	"github.com/mafredri/cdp/protocol/page"
+	"github.com/mafredri/cdp/rpcc"
+)
+
+// Session wraps a single debugged browser tab: its CDP client plus the RPC
+// connection backing it. Several Sessions can be driven concurrently from
+// one Manager, each with its own Network event stream.
+type Session struct {
+	Target *devtool.Target
+	Client *cdp.Client
+
+	conn   *rpcc.Conn
+	events *Dispatcher
+}
+
+// Attach connects to an already-open DevTools target and enables the
+// Network domain so response events can be observed on it.
+func Attach(ctx context.Context, target *devtool.Target) (*Session, error) {
+	conn, err := rpcc.DialContext(ctx, target.WebSocketDebuggerURL)
+	if err != nil {
+		return nil, fmt.Errorf("dialing %s: %w", target.WebSocketDebuggerURL, err)
+	}
+
+	client := cdp.NewClient(conn)
+	if err := client.Network.Enable(ctx, network.NewEnableArgs()); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("enabling Network domain: %w", err)
+	}
+
+	return &Session{Target: target, Client: client, conn: conn, events: newDispatcher(conn)}, nil
+}
+
+// Events returns the Session's event dispatcher, which fans out raw CDP
+// events by method name to every registered handler. Tasks normally go
+// through the OnResponse Action rather than calling this directly.
+func (s *Session) Events() *Dispatcher {
+	return s.events
+}
+
+// Navigate opens url in this session's tab.
+func (s *Session) Navigate(ctx context.Context, url string) error {
+	_, err := s.Client.Page.Navigate(ctx, page.NewNavigateArgs(url))
+	return err
+}
+
+// Close tears down the underlying RPC connection to the tab.
+func (s *Session) Close() error {
+	return s.conn.Close()
+}
@@ -0,0 +1,101 @@
+package browser
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/mafredri/cdp/protocol/network"
+	"github.com/mafredri/cdp/protocol/runtime"
+)
+
+// Action is a single step run against a Session. Tasks compose Actions in
+// order, which reads better than the previous raw response loop once a
+// scrape needs to log in, dismiss a cookie banner and pick a quality before
+// the audio listener is armed.
+type Action func(ctx context.Context, s *Session) error
+
+// Task is an ordered sequence of Actions.
+type Task []Action
+
+// Run executes every Action in t against s in order, stopping at the first
+// error.
+func (t Task) Run(ctx context.Context, s *Session) error {
+	for i, action := range t {
+		if err := action(ctx, s); err != nil {
+			return fmt.Errorf("task step %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// Navigate opens url in the tab.
+func Navigate(url string) Action {
+	return func(ctx context.Context, s *Session) error {
+		return s.Navigate(ctx, url)
+	}
+}
+
+// WaitVisible blocks until document.querySelector(selector) resolves to an
+// element with non-zero size, or ctx is done. Sites like Netflix only start
+// streaming audio once the viewer has an interacted-with, rendered page, so
+// tasks commonly wait on a play button or quality selector first.
+func WaitVisible(selector string) Action {
+	return func(ctx context.Context, s *Session) error {
+		js := fmt.Sprintf(`(() => {
+			const el = document.querySelector(%q);
+			if (!el) return false;
+			const r = el.getBoundingClientRect();
+			return r.width > 0 && r.height > 0;
+		})()`, selector)
+
+		for {
+			var visible bool
+			if err := evaluate(ctx, s, js, &visible); err != nil {
+				return err
+			}
+			if visible {
+				return nil
+			}
+
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(200 * time.Millisecond):
+			}
+		}
+	}
+}
+
+// Evaluate runs js in the tab's main frame and decodes its JSON result into
+// out, which may be nil to discard the result.
+func Evaluate(js string, out interface{}) Action {
+	return func(ctx context.Context, s *Session) error {
+		return evaluate(ctx, s, js, out)
+	}
+}
+
+func evaluate(ctx context.Context, s *Session, js string, out interface{}) error {
+	args := runtime.NewEvaluateArgs(js).SetReturnByValue(true)
+	reply, err := s.Client.Runtime.Evaluate(ctx, args)
+	if err != nil {
+		return err
+	}
+	if reply.ExceptionDetails != nil {
+		return fmt.Errorf("evaluating %q: %s", js, reply.ExceptionDetails.Text)
+	}
+	if out == nil || reply.Result.Value == nil {
+		return nil
+	}
+	return json.Unmarshal(reply.Result.Value, out)
+}
+
+// OnResponse arms handler against every Network response received on the
+// tab for the life of the Session. It doesn't block: it's typically the
+// last step in a Task, after any login or cookie-consent steps have run.
+func OnResponse(handler func(*network.Response)) Action {
+	return func(ctx context.Context, s *Session) error {
+		return s.Events().OnNetworkResponse(ctx, handler)
+	}
+}
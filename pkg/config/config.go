@@ -0,0 +1,101 @@
+// Package config loads narr's runtime configuration from a YAML/TOML file,
+// so deployment-specific values (debugger address, target titles, output
+// directory, concurrency, backoff, and which site adapter handles which
+// hostname) don't need to be hardcoded or threaded through a long flag list.
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// SiteConfig maps a hostname to the adapter.Adapter (by name) that should
+// handle audio URLs observed on it.
+type SiteConfig struct {
+	Adapter string `mapstructure:"adapter"`
+}
+
+// Config is narr's full runtime configuration.
+type Config struct {
+	DebugAddr   string        `mapstructure:"debug_addr"`
+	Chrome      string        `mapstructure:"chrome"`
+	Headless    bool          `mapstructure:"headless"`
+	OutDir      string        `mapstructure:"out_dir"`
+	Socket      string        `mapstructure:"socket"`
+	Concurrency int           `mapstructure:"concurrency"`
+	Segments    int           `mapstructure:"segments"`
+	Backoff     time.Duration `mapstructure:"backoff"`
+	Titles      []string      `mapstructure:"titles"`
+
+	LogLevel    string `mapstructure:"log_level"`
+	MetricsAddr string `mapstructure:"metrics_addr"`
+
+	Sites map[string]SiteConfig `mapstructure:"sites"`
+}
+
+// Load reads configuration from path, or from a narr.yaml/narr.toml/etc.
+// discovered in the working directory when path is empty, layering it over
+// narr's defaults. When path is empty and no such file is found, narr runs
+// on defaults alone; a path given explicitly that does not exist is an
+// error.
+func Load(path string) (*Config, error) {
+	v := viper.New()
+	setDefaults(v)
+
+	if path != "" {
+		v.SetConfigFile(path)
+	} else {
+		v.SetConfigName("narr")
+		v.AddConfigPath(".")
+	}
+
+	if err := v.ReadInConfig(); err != nil {
+		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
+			return nil, fmt.Errorf("reading config: %w", err)
+		}
+	}
+
+	var cfg Config
+	if err := v.Unmarshal(&cfg); err != nil {
+		return nil, fmt.Errorf("decoding config: %w", err)
+	}
+
+	return &cfg, nil
+}
+
+func setDefaults(v *viper.Viper) {
+	v.SetDefault("chrome", "chromium")
+	v.SetDefault("headless", true)
+	v.SetDefault("out_dir", ".")
+	v.SetDefault("socket", "/tmp/narr.sock")
+	v.SetDefault("concurrency", 8)
+	v.SetDefault("segments", 4)
+	v.SetDefault("backoff", 5*time.Second)
+	v.SetDefault("titles", []string{"https://www.netflix.com"})
+	v.SetDefault("log_level", "info")
+	v.SetDefault("metrics_addr", "127.0.0.1:9090")
+	v.SetDefault("sites", map[string]interface{}{
+		"netflix.com":     map[string]interface{}{"adapter": "netflix"},
+		"googlevideo.com": map[string]interface{}{"adapter": "youtube-music"},
+	})
+}
+
+// AdapterName returns the adapter name configured for host, matching on
+// exact hostname or any registered suffix (so "googlevideo.com" also
+// covers "rr1---sn-abc.googlevideo.com").
+func (c *Config) AdapterName(host string) (string, bool) {
+	if site, ok := c.Sites[host]; ok {
+		return site.Adapter, true
+	}
+
+	for suffix, site := range c.Sites {
+		if strings.HasSuffix(host, "."+suffix) {
+			return site.Adapter, true
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,279 @@
+// Package downloader implements a resumable, range-aware download engine.
+// Unlike a plain http.Get, it splits a download into parallel byte-range
+// segments, journals progress to a ".part" sidecar so an interrupted run
+// resumes instead of restarting, and records a rolling SHA-256 of the
+// assembled file in that journal. The recorded digest is not compared
+// against the file again: a later run that finds it treats the file as
+// complete without re-reading it.
+package downloader
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+const defaultSegments = 4
+
+// Downloader fetches resources into dir using parallel Range requests.
+type Downloader struct {
+	Client   *http.Client
+	Dir      string
+	Segments int // number of parallel Range requests per job, default 4
+}
+
+// New returns a Downloader writing into dir with sane defaults.
+func New(dir string) *Downloader {
+	return &Downloader{Client: http.DefaultClient, Dir: dir, Segments: defaultSegments}
+}
+
+// Job tracks one in-flight or completed download. Callers can poll
+// BytesWritten/TotalBytes for progress, or Wait for completion.
+type Job struct {
+	URL  string
+	Path string
+
+	total   int64
+	written int64 // atomic
+
+	done chan struct{}
+	err  error
+}
+
+// TotalBytes is the full size of the resource being downloaded.
+func (j *Job) TotalBytes() int64 { return j.total }
+
+// BytesWritten is how many bytes have landed on disk so far, across every
+// segment, including ones restored from a prior run's journal.
+func (j *Job) BytesWritten() int64 { return atomic.LoadInt64(&j.written) }
+
+// Wait blocks until the download finishes and returns its final error, if
+// any.
+func (j *Job) Wait() error {
+	<-j.done
+	return j.err
+}
+
+// Start begins downloading fetchURL, whose total size is already known
+// (e.g. parsed from a "/range/0-N" observation), resuming from any existing
+// journal for the destination file. nameSeed is used to derive a stable
+// filename (see FilenameFor) and is typically the original, un-stripped
+// resource URL, since fetchURL itself may have been rewritten to a bare
+// downloadable form. Start returns immediately with a Job that can be
+// waited on or polled for progress.
+func (d *Downloader) Start(ctx context.Context, fetchURL, nameSeed string, totalSize int64) (*Job, error) {
+	segments := d.Segments
+	if segments < 1 {
+		segments = defaultSegments
+	}
+
+	path := filepath.Join(d.Dir, FilenameFor(nameSeed))
+	journalPath := path + journalSuffix
+
+	j, err := loadJournal(journalPath)
+	if err != nil || j.Size != totalSize {
+		j = newJournal(fetchURL, path, totalSize, segments)
+	}
+
+	job := &Job{URL: fetchURL, Path: path, total: j.Size, done: make(chan struct{})}
+	for _, seg := range j.Segments {
+		if seg.Done {
+			job.written += seg.End - seg.Start + 1
+		}
+	}
+
+	if j.SHA256 != "" && j.HashedUpTo == j.Size {
+		// A prior run finished this file and recorded its digest; dedupe
+		// without re-reading the file.
+		close(job.done)
+		return job, nil
+	}
+
+	out, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("opening %s: %w", path, err)
+	}
+
+	client := d.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	go d.run(ctx, client, out, journalPath, j, job)
+
+	return job, nil
+}
+
+// run fetches every pending segment in parallel, journaling progress as
+// each one lands, then records the assembled file's final digest.
+func (d *Downloader) run(ctx context.Context, client *http.Client, out *os.File, journalPath string, j *journal, job *Job) {
+	defer out.Close()
+	defer close(job.done)
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		firstErr error
+	)
+
+	for i := range j.Segments {
+		seg := &j.Segments[i]
+		if seg.Done {
+			continue
+		}
+
+		wg.Add(1)
+		go func(seg *segment) {
+			defer wg.Done()
+
+			err := fetchSegment(ctx, client, job.URL, out, seg, &job.written)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = fmt.Errorf("segment %d-%d: %w", seg.Start, seg.End, err)
+				}
+				return
+			}
+
+			seg.Done = true
+			if err := advanceHash(j, out); err != nil && firstErr == nil {
+				firstErr = err
+			}
+			if err := j.save(journalPath); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}(seg)
+	}
+
+	wg.Wait()
+
+	if firstErr != nil {
+		job.err = firstErr
+		return
+	}
+
+	if j.HashedUpTo == j.Size {
+		j.SHA256 = hex.EncodeToString(mustHasherSum(j))
+		if err := j.save(journalPath); err != nil {
+			job.err = err
+			return
+		}
+	}
+}
+
+// fetchSegment issues a single Range request and streams it to the
+// segment's offset in out, tracking bytes written in written.
+func fetchSegment(ctx context.Context, client *http.Client, url string, out *os.File, seg *segment, written *int64) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", seg.Start, seg.End))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusPartialContent && resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	w := &offsetWriter{f: out, offset: seg.Start, written: written}
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
+// offsetWriter writes sequentially into an os.File starting at a fixed
+// offset, so concurrent segment downloads never overlap, and reports each
+// write to a shared progress counter.
+type offsetWriter struct {
+	f       *os.File
+	offset  int64
+	written *int64
+}
+
+func (w *offsetWriter) Write(p []byte) (int, error) {
+	n, err := w.f.WriteAt(p, w.offset)
+	w.offset += int64(n)
+	if n > 0 {
+		atomic.AddInt64(w.written, int64(n))
+	}
+	return n, err
+}
+
+// advanceHash folds any newly-completed, contiguous prefix of segments into
+// the journal's rolling SHA-256, so resuming a download never re-hashes
+// bytes already accounted for.
+func advanceHash(j *journal, f *os.File) error {
+	sorted := append([]segment(nil), j.Segments...)
+	sort.Slice(sorted, func(a, b int) bool { return sorted[a].Start < sorted[b].Start })
+
+	h, err := j.hasher()
+	if err != nil {
+		return err
+	}
+
+	for _, seg := range sorted {
+		if seg.End < j.HashedUpTo {
+			continue
+		}
+		if seg.Start != j.HashedUpTo || !seg.Done {
+			break
+		}
+
+		if _, err := io.Copy(h, io.NewSectionReader(f, seg.Start, seg.End-seg.Start+1)); err != nil {
+			return err
+		}
+		j.HashedUpTo = seg.End + 1
+	}
+
+	return j.saveHasher(h)
+}
+
+// mustHasherSum returns the finished digest for a fully-hashed journal. It
+// is only called once HashedUpTo == Size, so reconstructing the hasher
+// cannot fail in practice.
+func mustHasherSum(j *journal) []byte {
+	h, err := j.hasher()
+	if err != nil {
+		return sha256.New().Sum(nil)
+	}
+	return h.Sum(nil)
+}
+
+// FilenameFor derives a stable filename for rawURL so re-running narr
+// against the same resource dedupes onto the same file instead of writing
+// a new randomly-named one every time.
+func FilenameFor(rawURL string) string {
+	sum := sha256.Sum256([]byte(rawURL))
+	name := filepath.Base(rawURL)
+	if name == "" || name == "." || name == "/" {
+		name = "audio"
+	}
+	return fmt.Sprintf("%s-%s", sanitize(name), hex.EncodeToString(sum[:])[:16])
+}
+
+func sanitize(name string) string {
+	out := make([]rune, 0, len(name))
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-' || r == '_' || r == '.':
+			out = append(out, r)
+		default:
+			out = append(out, '_')
+		}
+	}
+	return string(out)
+}
@@ -0,0 +1,124 @@
+package downloader
+
+import (
+	"crypto/sha256"
+	"encoding"
+	"encoding/json"
+	"hash"
+	"os"
+)
+
+// journalSuffix names the sidecar file a Job's progress is persisted to,
+// next to the file being assembled.
+const journalSuffix = ".part"
+
+// segment is one byte range of the download, fetched by a single worker.
+type segment struct {
+	Start int64
+	End   int64 // inclusive
+	Done  bool
+}
+
+// journal is the on-disk, resumable state of a single download: which
+// segments have landed and how much of the file has been folded into the
+// rolling SHA-256 so far.
+type journal struct {
+	URL      string
+	Path     string
+	Size     int64
+	Segments []segment
+
+	// HashedUpTo is the offset up to which HashState has absorbed
+	// contiguous bytes from the start of the file.
+	HashedUpTo int64
+	HashState  []byte // serialized sha256.Hash, see hasher/setHasher
+	SHA256     string // final hex digest, set once HashedUpTo == Size
+}
+
+// newJournal splits size bytes into n roughly equal segments.
+func newJournal(url, path string, size int64, n int) *journal {
+	if n < 1 {
+		n = 1
+	}
+
+	segments := make([]segment, 0, n)
+	chunk := size / int64(n)
+	if chunk == 0 {
+		chunk = size
+	}
+
+	var start int64
+	for start < size {
+		end := start + chunk - 1
+		if end >= size-1 || len(segments) == n-1 {
+			end = size - 1
+		}
+		segments = append(segments, segment{Start: start, End: end})
+		start = end + 1
+	}
+
+	return &journal{URL: url, Path: path, Size: size, Segments: segments}
+}
+
+// hasher reconstructs the rolling SHA-256 state from the journal, so a
+// resumed download doesn't have to re-read bytes it already hashed.
+func (j *journal) hasher() (hash.Hash, error) {
+	h := sha256.New()
+	if len(j.HashState) == 0 {
+		return h, nil
+	}
+
+	unmarshaler, ok := h.(encoding.BinaryUnmarshaler)
+	if !ok {
+		return h, nil
+	}
+	if err := unmarshaler.UnmarshalBinary(j.HashState); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// saveHasher persists h's internal state so the next resume can continue
+// hashing from HashedUpTo instead of from the start of the file.
+func (j *journal) saveHasher(h hash.Hash) error {
+	marshaler, ok := h.(encoding.BinaryMarshaler)
+	if !ok {
+		return nil
+	}
+
+	state, err := marshaler.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	j.HashState = state
+	return nil
+}
+
+// loadJournal reads a previously persisted journal, if any.
+func loadJournal(path string) (*journal, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var j journal
+	if err := json.Unmarshal(data, &j); err != nil {
+		return nil, err
+	}
+	return &j, nil
+}
+
+// save atomically persists j to path so a crash mid-write never leaves a
+// corrupt journal behind.
+func (j *journal) save(path string) error {
+	data, err := json.Marshal(j)
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
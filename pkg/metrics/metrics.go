@@ -0,0 +1,74 @@
+// Package metrics holds narr's Prometheus instrumentation and the HTTP
+// server that exposes it, alongside a pprof endpoint for diagnosing stalls
+// in a long-running capture.
+package metrics
+
+import (
+	"net/http"
+	"net/http/pprof"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// DownloadsTotal counts finished downloads by their terminal state
+	// ("done", "error", "cancelled").
+	DownloadsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "narr_downloads_total",
+		Help: "Downloads narr has finished, by terminal state.",
+	}, []string{"state"})
+
+	// DownloadBytes counts bytes written to disk across every download.
+	DownloadBytes = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "narr_download_bytes",
+		Help: "Total bytes narr has written to disk across all downloads.",
+	})
+
+	// DownloadDuration observes how long a download took from Start to its
+	// terminal state.
+	DownloadDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "narr_download_duration_seconds",
+		Help:    "How long a download job took from start to its terminal state.",
+		Buckets: prometheus.DefBuckets,
+	})
+
+	// CDPEventsTotal counts CDP events narr's event dispatcher has fanned
+	// out, by method name.
+	CDPEventsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "narr_cdp_events_total",
+		Help: "CDP events narr has dispatched, by method.",
+	}, []string{"method"})
+
+	// QueueDepth is the number of download jobs currently queued or
+	// running.
+	QueueDepth = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "narr_queue_depth",
+		Help: "Number of download jobs currently queued or running.",
+	})
+)
+
+// Serve starts an HTTP server on addr exposing /metrics and /debug/pprof/*
+// in the background. The caller should Close or Shutdown the returned
+// server alongside the rest of narr.
+func Serve(addr string, log *logrus.Logger) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	srv := &http.Server{Addr: addr, Handler: mux}
+
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.WithError(err).Error("metrics server stopped")
+		}
+	}()
+
+	return srv
+}
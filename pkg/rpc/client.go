@@ -0,0 +1,42 @@
+package rpc
+
+import (
+	"fmt"
+	"net/rpc/jsonrpc"
+	"os"
+	"text/tabwriter"
+)
+
+// PrintStatus dials the control socket at socketPath and prints a table of
+// every download a running narr knows about, for the "-status" CLI flag.
+func PrintStatus(socketPath string) error {
+	client, err := jsonrpc.Dial("unix", socketPath)
+	if err != nil {
+		return fmt.Errorf("dialing %s: %w", socketPath, err)
+	}
+	defer client.Close()
+
+	var statuses []DownloadStatus
+	if err := client.Call("Status.Downloads", struct{}{}, &statuses); err != nil {
+		return fmt.Errorf("Status.Downloads: %w", err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 2, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tSTATE\tBYTES\tTOTAL\tURL")
+	for _, s := range statuses {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%s\n", s.ID, s.State, s.BytesWritten, s.TotalBytes, s.URL)
+	}
+	return w.Flush()
+}
+
+// Cancel dials the control socket at socketPath and cancels a single job by
+// ID, for the "-cancel" CLI flag.
+func Cancel(socketPath, id string) error {
+	client, err := jsonrpc.Dial("unix", socketPath)
+	if err != nil {
+		return fmt.Errorf("dialing %s: %w", socketPath, err)
+	}
+	defer client.Close()
+
+	return client.Call("Queue.Cancel", id, &struct{}{})
+}
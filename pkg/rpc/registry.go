@@ -0,0 +1,177 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Benjamin-Loison/narr/pkg/downloader"
+)
+
+// State is a download job's place in its lifecycle, as reported over RPC.
+type State string
+
+const (
+	StateRunning   State = "running"
+	StateDone      State = "done"
+	StateError     State = "error"
+	StateCancelled State = "cancelled"
+)
+
+// DownloadStatus is a point-in-time snapshot of one job.
+type DownloadStatus struct {
+	ID           string
+	URL          string
+	Path         string
+	BytesWritten int64
+	TotalBytes   int64
+	State        State
+	Error        string
+	StartedAt    time.Time
+}
+
+// trackedJob is a Registry's private bookkeeping for one download.
+type trackedJob struct {
+	id        string
+	url       string
+	cancel    context.CancelFunc
+	startedAt time.Time
+
+	mu     sync.Mutex
+	job    *downloader.Job // nil until the downloader.Job exists
+	state  State
+	errMsg string
+}
+
+// Registry is the shared record of every download narr has started. The
+// enqueueDownload path registers a job here before handing it to the
+// downloader, and the RPC server reads it back for Status.Downloads,
+// Queue.Pause/Resume and Queue.Cancel.
+type Registry struct {
+	mu     sync.Mutex
+	jobs   map[string]*trackedJob
+	nextID int
+	gate   chan struct{} // closed while the queue is accepting new work
+}
+
+// NewRegistry returns an empty, unpaused Registry.
+func NewRegistry() *Registry {
+	gate := make(chan struct{})
+	close(gate)
+	return &Registry{jobs: make(map[string]*trackedJob), gate: gate}
+}
+
+// Wait blocks while the queue is paused, returning early if ctx is done.
+func (r *Registry) Wait(ctx context.Context) error {
+	r.mu.Lock()
+	gate := r.gate
+	r.mu.Unlock()
+
+	select {
+	case <-gate:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Pause stops new downloads from starting until Resume is called. Jobs
+// already in flight are unaffected.
+func (r *Registry) Pause() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	select {
+	case <-r.gate:
+		r.gate = make(chan struct{})
+	default:
+	}
+}
+
+// Resume lets downloads blocked on Wait proceed again.
+func (r *Registry) Resume() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	select {
+	case <-r.gate:
+	default:
+		close(r.gate)
+	}
+}
+
+// Register starts tracking a new job under a fresh ID and returns a context
+// derived from ctx that Cancel will cancel, plus two callbacks: attach,
+// which records the downloader.Job once Start has returned one, and finish,
+// which records the job's terminal state.
+func (r *Registry) Register(ctx context.Context, url string) (id string, jobCtx context.Context, attach func(*downloader.Job), finish func(error)) {
+	jobCtx, cancel := context.WithCancel(ctx)
+
+	r.mu.Lock()
+	r.nextID++
+	id = fmt.Sprintf("job-%d", r.nextID)
+	t := &trackedJob{id: id, url: url, cancel: cancel, startedAt: time.Now(), state: StateRunning}
+	r.jobs[id] = t
+	r.mu.Unlock()
+
+	attach = func(dj *downloader.Job) {
+		t.mu.Lock()
+		t.job = dj
+		t.mu.Unlock()
+	}
+
+	finish = func(err error) {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		switch {
+		case err == nil:
+			t.state = StateDone
+		case jobCtx.Err() != nil:
+			t.state = StateCancelled
+		default:
+			t.state = StateError
+			t.errMsg = err.Error()
+		}
+	}
+
+	return id, jobCtx, attach, finish
+}
+
+// Snapshot returns the current status of every job the Registry knows
+// about, in no particular order.
+func (r *Registry) Snapshot() []DownloadStatus {
+	r.mu.Lock()
+	tracked := make([]*trackedJob, 0, len(r.jobs))
+	for _, t := range r.jobs {
+		tracked = append(tracked, t)
+	}
+	r.mu.Unlock()
+
+	out := make([]DownloadStatus, 0, len(tracked))
+	for _, t := range tracked {
+		t.mu.Lock()
+		s := DownloadStatus{ID: t.id, URL: t.url, State: t.state, Error: t.errMsg, StartedAt: t.startedAt}
+		if t.job != nil {
+			s.Path = t.job.Path
+			s.BytesWritten = t.job.BytesWritten()
+			s.TotalBytes = t.job.TotalBytes()
+		}
+		t.mu.Unlock()
+		out = append(out, s)
+	}
+	return out
+}
+
+// Cancel stops a tracked job by ID, aborting any in-flight Range requests.
+func (r *Registry) Cancel(id string) error {
+	r.mu.Lock()
+	t, ok := r.jobs[id]
+	r.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("no such job %q", id)
+	}
+
+	t.cancel()
+	return nil
+}
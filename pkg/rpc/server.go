@@ -0,0 +1,91 @@
+// Package rpc exposes a running narr process's download queue over a
+// Unix-socket JSON-RPC endpoint, so an operator can inspect or control a
+// long capture without narr's queue otherwise being opaque from the
+// outside.
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"os"
+)
+
+// Server serves a Registry's Status and Queue RPC methods on a Unix socket.
+type Server struct {
+	listener net.Listener
+}
+
+// Serve starts listening on socketPath, removing any stale socket file left
+// behind by a prior, uncleanly-stopped run, and serves requests in the
+// background until ctx is done or Close is called.
+func Serve(ctx context.Context, socketPath string, reg *Registry) (*Server, error) {
+	_ = os.Remove(socketPath)
+
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return nil, fmt.Errorf("listening on %s: %w", socketPath, err)
+	}
+
+	server := rpc.NewServer()
+	if err := server.RegisterName("Status", &statusService{reg}); err != nil {
+		return nil, err
+	}
+	if err := server.RegisterName("Queue", &queueService{reg}); err != nil {
+		return nil, err
+	}
+
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.ServeCodec(jsonrpc.NewServerCodec(conn))
+		}
+	}()
+
+	return &Server{listener: listener}, nil
+}
+
+// Close stops accepting new RPC connections.
+func (s *Server) Close() error {
+	return s.listener.Close()
+}
+
+// statusService implements the Status.* RPC methods.
+type statusService struct{ reg *Registry }
+
+// Downloads returns a snapshot of every job the Registry knows about. args
+// is unused but required by the net/rpc calling convention.
+func (s *statusService) Downloads(args *struct{}, reply *[]DownloadStatus) error {
+	*reply = s.reg.Snapshot()
+	return nil
+}
+
+// queueService implements the Queue.* RPC methods.
+type queueService struct{ reg *Registry }
+
+// Pause stops new downloads from starting until Resume is called.
+func (q *queueService) Pause(args *struct{}, reply *struct{}) error {
+	q.reg.Pause()
+	return nil
+}
+
+// Resume lets downloads paused by Pause start again.
+func (q *queueService) Resume(args *struct{}, reply *struct{}) error {
+	q.reg.Resume()
+	return nil
+}
+
+// Cancel stops the job identified by args, aborting its in-flight requests.
+func (q *queueService) Cancel(id *string, reply *struct{}) error {
+	return q.reg.Cancel(*id)
+}